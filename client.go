@@ -2,11 +2,15 @@ package rollbar
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +27,18 @@ type Client interface {
 	// root: Path to the application code root, not including the final slash.
 	// Used to collapse non-project code when displaying tracebacks.
 	SetServerRoot(serverRoot string)
+	// SetTransport overrides how built payloads are delivered to Rollbar.
+	SetTransport(transport Transport)
+	// SetErrorHandler registers a callback invoked whenever the configured
+	// HTTPTransport drops an item it could not deliver to Rollbar. It has no
+	// effect when the Transport is not an *HTTPTransport.
+	SetErrorHandler(handler func(error))
+	// SetPerson identifies the end user associated with every subsequently
+	// reported item. Pass empty strings to stop attaching person data.
+	SetPerson(id, username, email string)
+	// SetCustom sets data merged into the "custom" field of every
+	// subsequently reported item.
+	SetCustom(custom map[string]interface{})
 
 	Error(level string, err error)
 	ErrorWithExtras(level string, err error, extras map[string]interface{})
@@ -37,6 +53,20 @@ type Client interface {
 	Message(level string, msg string)
 	MessageWithExtras(level string, msg string, extras map[string]interface{})
 
+	// SyncError, SyncMessage and SyncRequestError bypass bodyChannel and
+	// block until the item has been delivered (or permanently failed),
+	// returning the item UUID assigned by Rollbar.
+	SyncError(level string, err error) (string, error)
+	SyncMessage(level string, msg string) (string, error)
+	SyncRequestError(level string, r *http.Request, err error) (string, error)
+
+	// ErrorWithContext, MessageWithContext and RequestErrorWithContext are
+	// the context-aware siblings of the Sync* methods above, letting
+	// callers bound a send with a deadline.
+	ErrorWithContext(ctx context.Context, level string, err error) (string, error)
+	MessageWithContext(ctx context.Context, level string, msg string) (string, error)
+	RequestErrorWithContext(ctx context.Context, level string, r *http.Request, err error) (string, error)
+
 	Wait()
 }
 
@@ -63,25 +93,53 @@ type Rollbar struct {
 	// root: Path to the application code root, not including the final slash.
 	// Used to collapse non-project code when displaying tracebacks.
 	ServerRoot string
+	// Transport delivers built payloads to Rollbar. Defaults to an
+	// HTTPTransport with retry and backoff.
+	Transport Transport
+	// Person identifies the end user associated with every reported item,
+	// if set via SetPerson.
+	Person *Person
+	// Custom is global data merged into the "custom" field of every
+	// reported item, if set via SetCustom.
+	Custom map[string]interface{}
+	// MaxPayloadSize caps the serialized size, in bytes, of any single
+	// extras or custom field. Fields over the cap are replaced with a
+	// truncation marker rather than risking outright rejection by
+	// Rollbar. Zero disables the cap.
+	MaxPayloadSize int
 	// Queue of messages to be sent.
 	bodyChannel chan map[string]interface{}
 	waitGroup   sync.WaitGroup
 }
 
+// Person identifies the end user associated with a reported item, as set by
+// SetPerson.
+type Person struct {
+	Id       string `json:"id"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// DefaultMaxPayloadSize is the MaxPayloadSize a Rollbar created via New
+// starts with.
+const DefaultMaxPayloadSize = 256 * 1024
+
 // New returns the default implementation of a Client
 func New(token, environment, codeVersion, serverHost, serverRoot string) Client {
 	buffer := 1000
 	client := &Rollbar{
-		Token:         token,
-		Environment:   environment,
-		Endpoint:      "https://api.rollbar.com/api/1/item/",
-		Buffer:        1000,
-		FilterHeaders: regexp.MustCompile("Authorization"),
-		FilterFields:  regexp.MustCompile("password|secret|token"),
-		CodeVersion:   codeVersion,
-		ServerHost:    serverHost,
-		ServerRoot:    serverRoot,
-		bodyChannel:   make(chan map[string]interface{}, buffer),
+		Token:          token,
+		Environment:    environment,
+		Endpoint:       "https://api.rollbar.com/api/1/item/",
+		Buffer:         1000,
+		FilterHeaders:  regexp.MustCompile("Authorization"),
+		FilterFields:   regexp.MustCompile("password|secret|token"),
+		CodeVersion:    codeVersion,
+		ServerHost:     serverHost,
+		ServerRoot:     serverRoot,
+		Transport:      NewHTTPTransport(),
+		MaxPayloadSize: DefaultMaxPayloadSize,
+		bodyChannel:    make(chan map[string]interface{}, buffer),
 	}
 
 	go func() {
@@ -113,6 +171,34 @@ func (c *Rollbar) SetServerRoot(serverRoot string) {
 	c.ServerRoot = serverRoot
 }
 
+func (c *Rollbar) SetTransport(transport Transport) {
+	c.Transport = transport
+}
+
+func (c *Rollbar) SetErrorHandler(handler func(error)) {
+	if t, ok := c.Transport.(*HTTPTransport); ok {
+		t.errorHandler = handler
+	}
+}
+
+// SetPerson identifies the end user associated with every subsequently
+// reported item. Rollbar requires a non-empty id, so an empty id clears any
+// previously set person regardless of username/email. Like the other
+// Set* methods, this mutates shared client state and isn't meant to be
+// called concurrently with reporting -- use per-request extras instead if
+// person data varies per request.
+func (c *Rollbar) SetPerson(id, username, email string) {
+	if id == "" {
+		c.Person = nil
+		return
+	}
+	c.Person = &Person{Id: id, Username: username, Email: email}
+}
+
+func (c *Rollbar) SetCustom(custom map[string]interface{}) {
+	c.Custom = custom
+}
+
 // -- Error reporting
 
 var noExtras map[string]interface{}
@@ -228,7 +314,19 @@ func (c *Rollbar) buildBody(level, title string, extras map[string]interface{})
 	}
 
 	for k, v := range extras {
-		data[k] = v
+		if k == "custom" {
+			continue // merged with the global Custom below, then truncated once
+		}
+		data[k] = c.truncateOversize(v)
+	}
+
+	if c.Person != nil {
+		if _, ok := data["person"]; !ok {
+			data["person"] = c.Person
+		}
+	}
+	if custom := c.mergedCustom(extras); custom != nil {
+		data["custom"] = c.truncateOversize(custom)
 	}
 
 	return map[string]interface{}{
@@ -237,11 +335,63 @@ func (c *Rollbar) buildBody(level, title string, extras map[string]interface{})
 	}
 }
 
+// mergedCustom combines the global Custom data with any call-specific
+// "custom" extra, with the call-specific value taking precedence on key
+// conflicts. It returns nil if there is nothing to attach. The result is
+// merged before size truncation is applied, so a merged field is never
+// truncated twice and the marker from truncating a too-large per-call
+// value can't be merged back into as if it were real data.
+func (c *Rollbar) mergedCustom(extras map[string]interface{}) interface{} {
+	raw, hasCustom := extras["custom"]
+	if len(c.Custom) == 0 {
+		if !hasCustom {
+			return nil
+		}
+		return raw
+	}
+
+	local, ok := raw.(map[string]interface{})
+	if !ok {
+		if hasCustom {
+			return raw
+		}
+		return c.Custom
+	}
+
+	merged := make(map[string]interface{}, len(c.Custom)+len(local))
+	for k, v := range c.Custom {
+		merged[k] = v
+	}
+	for k, v := range local {
+		merged[k] = v
+	}
+	return merged
+}
+
+// truncateOversize replaces value with a truncation marker if its encoded
+// size exceeds MaxPayloadSize, so that a single oversize extras or custom
+// field can't get the whole item silently rejected by Rollbar.
+func (c *Rollbar) truncateOversize(value interface{}) interface{} {
+	if c.MaxPayloadSize <= 0 {
+		return value
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil || len(encoded) <= c.MaxPayloadSize {
+		return value
+	}
+
+	return map[string]interface{}{
+		"truncated":     true,
+		"original_size": len(encoded),
+	}
+}
+
 // Extract error details from a Request to a format that Rollbar accepts.
 func (c *Rollbar) errorRequest(r *http.Request) map[string]interface{} {
 	cleanQuery := filterParams(c.FilterFields, r.URL.Query())
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"url":     r.URL.String(),
 		"method":  r.Method,
 		"headers": flattenValues(filterParams(c.FilterHeaders, r.Header)),
@@ -253,6 +403,60 @@ func (c *Rollbar) errorRequest(r *http.Request) map[string]interface{} {
 		// POST / PUT params
 		"POST": flattenValues(filterParams(c.FilterFields, r.Form)),
 	}
+
+	if body, ok := c.scrubbedJSONBody(r); ok {
+		result["body"] = body
+	}
+
+	return result
+}
+
+// scrubbedJSONBody reads and restores a JSON request body, redacting any
+// key at any nesting level that matches FilterFields. It reports false if
+// the request has no JSON body to scrub.
+func (c *Rollbar) scrubbedJSONBody(r *http.Request) (interface{}, bool) {
+	if r.Body == nil || !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return nil, false
+	}
+
+	raw, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+	if err != nil || len(raw) == 0 {
+		return nil, false
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, false
+	}
+
+	return scrubJSON(c.FilterFields, parsed), true
+}
+
+// scrubJSON recursively redacts object keys matching pattern anywhere in a
+// decoded JSON value.
+func scrubJSON(pattern *regexp.Regexp, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		scrubbed := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if pattern.MatchString(key) {
+				scrubbed[key] = FILTERED
+			} else {
+				scrubbed[key] = scrubJSON(pattern, val)
+			}
+		}
+		return scrubbed
+	case []interface{}:
+		scrubbed := make([]interface{}, len(v))
+		for i, val := range v {
+			scrubbed[i] = scrubJSON(pattern, val)
+		}
+		return scrubbed
+	default:
+		return v
+	}
 }
 
 // filterParams filters sensitive information like passwords from being sent to
@@ -293,26 +497,15 @@ func (c *Rollbar) push(body map[string]interface{}) {
 	}
 }
 
-// POST the given JSON body to Rollbar synchronously.
-func (c *Rollbar) post(body map[string]interface{}) {
+// POST the given JSON body to Rollbar, via the configured Transport. Returns
+// the item UUID assigned by Rollbar, if any, and an error if the item could
+// not be delivered.
+func (c *Rollbar) post(body map[string]interface{}) (string, error) {
 	if len(c.Token) == 0 {
-		rollbarError("empty token")
-		return
+		err := fmt.Errorf("empty token")
+		rollbarError(err.Error())
+		return "", err
 	}
 
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		rollbarError("failed to encode payload: %s", err.Error())
-		return
-	}
-
-	resp, err := http.Post(c.Endpoint, "application/json", bytes.NewReader(jsonBody))
-	if err != nil {
-		rollbarError("POST failed: %s", err.Error())
-	} else if resp.StatusCode != 200 {
-		rollbarError("received response: %s", resp.Status)
-	}
-	if resp != nil {
-		resp.Body.Close()
-	}
+	return c.Transport.Post(c.Endpoint, body)
 }
@@ -0,0 +1,175 @@
+package rollbar
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSetPersonRequiresNonEmptyID(t *testing.T) {
+	c := &Rollbar{}
+
+	c.SetPerson("42", "alice", "alice@example.com")
+	if c.Person == nil || c.Person.Id != "42" {
+		t.Fatalf("Person = %+v, want id 42", c.Person)
+	}
+
+	c.SetPerson("", "alice", "alice@example.com")
+	if c.Person != nil {
+		t.Errorf("Person = %+v, want nil after clearing with an empty id", c.Person)
+	}
+}
+
+func TestMergedCustomMergesGlobalAndPerCall(t *testing.T) {
+	c := &Rollbar{Custom: map[string]interface{}{"region": "us", "tier": "free"}}
+
+	got := c.mergedCustom(map[string]interface{}{"custom": map[string]interface{}{"tier": "paid"}})
+	merged, ok := got.(map[string]interface{})
+	if !ok {
+		t.Fatalf("mergedCustom() = %T, want map[string]interface{}", got)
+	}
+	if merged["region"] != "us" {
+		t.Errorf("region = %v, want us (from global Custom)", merged["region"])
+	}
+	if merged["tier"] != "paid" {
+		t.Errorf("tier = %v, want paid (per-call should win on conflict)", merged["tier"])
+	}
+}
+
+func TestMergedCustomFallsBackToGlobalOnly(t *testing.T) {
+	c := &Rollbar{Custom: map[string]interface{}{"region": "us"}}
+
+	got := c.mergedCustom(nil)
+	merged, ok := got.(map[string]interface{})
+	if !ok || merged["region"] != "us" {
+		t.Fatalf("mergedCustom() = %v, want global Custom", got)
+	}
+}
+
+func TestMergedCustomReturnsNilWithNothingToAttach(t *testing.T) {
+	c := &Rollbar{}
+	if got := c.mergedCustom(nil); got != nil {
+		t.Errorf("mergedCustom() = %v, want nil", got)
+	}
+}
+
+func TestMergedCustomIsTruncatedOnlyOnce(t *testing.T) {
+	c := &Rollbar{
+		Custom:         map[string]interface{}{"region": "us"},
+		MaxPayloadSize: 10,
+	}
+
+	big := map[string]interface{}{"blob": strings.Repeat("x", 100)}
+	merged := c.mergedCustom(map[string]interface{}{"custom": big})
+	truncated := c.truncateOversize(merged)
+
+	data, ok := truncated.(map[string]interface{})
+	if !ok {
+		t.Fatalf("truncateOversize() = %T, want the truncation marker map", truncated)
+	}
+	if data["truncated"] != true {
+		t.Errorf("truncated = %v, want true", data["truncated"])
+	}
+	if _, hasRegion := data["region"]; hasRegion {
+		t.Error("global Custom keys leaked into the truncation marker map")
+	}
+}
+
+func TestTruncateOversizeDisabledWhenCapIsZero(t *testing.T) {
+	c := &Rollbar{MaxPayloadSize: 0}
+	big := strings.Repeat("x", 1000)
+	if got := c.truncateOversize(big); got != big {
+		t.Errorf("truncateOversize() = %v, want the value unchanged", got)
+	}
+}
+
+func TestTruncateOversizeLeavesSmallValuesAlone(t *testing.T) {
+	c := &Rollbar{MaxPayloadSize: 1024}
+	small := map[string]interface{}{"a": 1}
+	got := c.truncateOversize(small)
+	m, ok := got.(map[string]interface{})
+	if !ok || m["a"] != 1 {
+		t.Errorf("truncateOversize() = %v, want the value unchanged", got)
+	}
+}
+
+func TestScrubJSONRedactsNestedKeys(t *testing.T) {
+	pattern := regexp.MustCompile("password|secret|token")
+	input := map[string]interface{}{
+		"username": "alice",
+		"password": "hunter2",
+		"nested": map[string]interface{}{
+			"token": "abc",
+			"list": []interface{}{
+				map[string]interface{}{"secret": "xyz", "ok": "fine"},
+			},
+		},
+	}
+
+	got := scrubJSON(pattern, input).(map[string]interface{})
+	if got["username"] != "alice" {
+		t.Errorf("username = %v, want alice", got["username"])
+	}
+	if got["password"] != FILTERED {
+		t.Errorf("password = %v, want %v", got["password"], FILTERED)
+	}
+
+	nested := got["nested"].(map[string]interface{})
+	if nested["token"] != FILTERED {
+		t.Errorf("nested.token = %v, want %v", nested["token"], FILTERED)
+	}
+
+	list := nested["list"].([]interface{})
+	inner := list[0].(map[string]interface{})
+	if inner["secret"] != FILTERED {
+		t.Errorf("secret = %v, want %v", inner["secret"], FILTERED)
+	}
+	if inner["ok"] != "fine" {
+		t.Errorf("ok = %v, want fine", inner["ok"])
+	}
+}
+
+func TestScrubbedJSONBodyRestoresBodyForLaterReaders(t *testing.T) {
+	c := &Rollbar{FilterFields: regexp.MustCompile("password")}
+
+	raw := `{"username":"alice","password":"hunter2"}`
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(raw))
+	req.Header.Set("Content-Type", "application/json")
+
+	scrubbed, ok := c.scrubbedJSONBody(req)
+	if !ok {
+		t.Fatal("expected a scrubbed body")
+	}
+	body := scrubbed.(map[string]interface{})
+	if body["password"] != FILTERED {
+		t.Errorf("password = %v, want %v", body["password"], FILTERED)
+	}
+
+	restored, err := ioutilReadAll(req)
+	if err != nil {
+		t.Fatalf("unexpected error re-reading body: %s", err)
+	}
+	if restored != raw {
+		t.Errorf("request body after scrubbing = %q, want the original %q restored", restored, raw)
+	}
+}
+
+func TestScrubbedJSONBodyIgnoresNonJSONRequests(t *testing.T) {
+	c := &Rollbar{FilterFields: regexp.MustCompile("password")}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("not json"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	if _, ok := c.scrubbedJSONBody(req); ok {
+		t.Error("expected scrubbedJSONBody to skip a non-JSON request")
+	}
+}
+
+func ioutilReadAll(r *http.Request) (string, error) {
+	buf := new(bytes.Buffer)
+	_, err := buf.ReadFrom(r.Body)
+	return buf.String(), err
+}
@@ -0,0 +1,106 @@
+package rollbar
+
+import (
+	"context"
+	"net/http"
+)
+
+// recordedCall captures the arguments a fakeClient method was invoked
+// with, for assertions in tests that exercise code depending only on the
+// Client interface (e.g. Middleware).
+type recordedCall struct {
+	method  string
+	level   string
+	err     error
+	msg     string
+	request *http.Request
+	extras  map[string]interface{}
+}
+
+// fakeClient is a minimal Client implementation that records every call
+// instead of making network requests.
+type fakeClient struct {
+	calls []recordedCall
+	waits int
+}
+
+func (f *fakeClient) SetToken(string)                     {}
+func (f *fakeClient) SetEnvironment(string)                {}
+func (f *fakeClient) SetCodeVersion(string)                {}
+func (f *fakeClient) SetServerHost(string)                 {}
+func (f *fakeClient) SetServerRoot(string)                 {}
+func (f *fakeClient) SetTransport(Transport)               {}
+func (f *fakeClient) SetErrorHandler(func(error))          {}
+func (f *fakeClient) SetPerson(id, username, email string) {}
+func (f *fakeClient) SetCustom(map[string]interface{})     {}
+
+func (f *fakeClient) Error(level string, err error) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) ErrorWithExtras(level string, err error, extras map[string]interface{}) {
+	f.calls = append(f.calls, recordedCall{method: "Error", level: level, err: err, extras: extras})
+}
+
+func (f *fakeClient) ErrorWithStackSkip(level string, err error, skip int) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) ErrorWithStackSkipWithExtras(level string, err error, skip int, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) RequestError(level string, r *http.Request, err error) {
+	f.RequestErrorWithExtras(level, r, err, nil)
+}
+
+func (f *fakeClient) RequestErrorWithExtras(level string, r *http.Request, err error, extras map[string]interface{}) {
+	f.calls = append(f.calls, recordedCall{method: "RequestError", level: level, err: err, request: r, extras: extras})
+}
+
+func (f *fakeClient) RequestErrorWithStackSkip(level string, r *http.Request, err error, skip int) {
+	f.RequestErrorWithExtras(level, r, err, nil)
+}
+
+func (f *fakeClient) RequestErrorWithStackSkipWithExtras(level string, r *http.Request, err error, skip int, extras map[string]interface{}) {
+	f.RequestErrorWithExtras(level, r, err, extras)
+}
+
+func (f *fakeClient) Message(level string, msg string) {
+	f.MessageWithExtras(level, msg, nil)
+}
+
+func (f *fakeClient) MessageWithExtras(level string, msg string, extras map[string]interface{}) {
+	f.calls = append(f.calls, recordedCall{method: "Message", level: level, msg: msg, extras: extras})
+}
+
+func (f *fakeClient) SyncError(level string, err error) (string, error) {
+	f.ErrorWithExtras(level, err, nil)
+	return "fake-uuid", nil
+}
+
+func (f *fakeClient) SyncMessage(level string, msg string) (string, error) {
+	f.MessageWithExtras(level, msg, nil)
+	return "fake-uuid", nil
+}
+
+func (f *fakeClient) SyncRequestError(level string, r *http.Request, err error) (string, error) {
+	f.RequestErrorWithExtras(level, r, err, nil)
+	return "fake-uuid", nil
+}
+
+func (f *fakeClient) ErrorWithContext(ctx context.Context, level string, err error) (string, error) {
+	return f.SyncError(level, err)
+}
+
+func (f *fakeClient) MessageWithContext(ctx context.Context, level string, msg string) (string, error) {
+	return f.SyncMessage(level, msg)
+}
+
+func (f *fakeClient) RequestErrorWithContext(ctx context.Context, level string, r *http.Request, err error) (string, error) {
+	return f.SyncRequestError(level, r, err)
+}
+
+func (f *fakeClient) Wait() {
+	f.waits++
+}
@@ -0,0 +1,164 @@
+package rollbar
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader is the HTTP header used to carry the per-request UUID that
+// Middleware attaches to every request, both on the response and in the
+// extras sent to Rollbar. Middleware honors this header if the incoming
+// request already set it, rather than generating a new id.
+const RequestIDHeader = "X-Request-Id"
+
+// MiddlewareOption configures the behavior of Middleware and MiddlewareFunc.
+type MiddlewareOption func(*middleware)
+
+// WithRequestIDHeader overrides the header name used to read and write the
+// per-request id. The default is RequestIDHeader.
+func WithRequestIDHeader(header string) MiddlewareOption {
+	return func(m *middleware) {
+		m.requestIDHeader = header
+	}
+}
+
+// WithWarningStatus overrides the status code at and above which a response
+// is reported to Rollbar at the "warning" level. The default is 400. Status
+// codes at or above the error threshold (see WithErrorStatus) take
+// precedence.
+func WithWarningStatus(status int) MiddlewareOption {
+	return func(m *middleware) {
+		m.warningStatus = status
+	}
+}
+
+// WithErrorStatus overrides the status code at and above which a response is
+// reported to Rollbar at the "error" level. The default is 500.
+func WithErrorStatus(status int) MiddlewareOption {
+	return func(m *middleware) {
+		m.errorStatus = status
+	}
+}
+
+// Middleware wraps next, reporting panics and error-range responses to
+// client. It recovers any panic raised by next, reports it via
+// RequestErrorWithStackSkipWithExtras using the panic's stack, and responds
+// with a 500 rather than crashing the server. Responses with a status code
+// at or above the error threshold are reported at the "error" level,
+// responses at or above the warning threshold are reported at the "warning"
+// level.
+//
+// A UUID is generated for every request, unless the incoming request already
+// carries one in the request-id header, and is attached both to the
+// response and to the extras sent to Rollbar so that a Rollbar item can be
+// cross-referenced with application logs.
+func Middleware(client Client, next http.Handler, opts ...MiddlewareOption) http.Handler {
+	m := &middleware{
+		client:          client,
+		next:            next,
+		requestIDHeader: RequestIDHeader,
+		warningStatus:   400,
+		errorStatus:     500,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// MiddlewareFunc is the equivalent of Middleware for a plain handler
+// function.
+func MiddlewareFunc(client Client, next func(http.ResponseWriter, *http.Request), opts ...MiddlewareOption) http.Handler {
+	return Middleware(client, http.HandlerFunc(next), opts...)
+}
+
+type middleware struct {
+	client          Client
+	next            http.Handler
+	requestIDHeader string
+	warningStatus   int
+	errorStatus     int
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(m.requestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	w.Header().Set(m.requestIDHeader, requestID)
+
+	sw := &statusWriter{ResponseWriter: w}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			extras := map[string]interface{}{"request_id": requestID}
+			m.client.RequestErrorWithStackSkipWithExtras("critical", r, panicError(rec), 2, extras)
+			if !sw.wroteHeader {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	}()
+
+	m.next.ServeHTTP(sw, r)
+
+	if level := m.levelForStatus(sw.status); level != "" {
+		extras := map[string]interface{}{
+			"request_id":  requestID,
+			"status_code": sw.status,
+		}
+		m.client.RequestErrorWithExtras(level, r, fmt.Errorf("response returned status %d", sw.status), extras)
+	}
+}
+
+func (m *middleware) levelForStatus(status int) string {
+	switch {
+	case status >= m.errorStatus:
+		return "error"
+	case status >= m.warningStatus:
+		return "warning"
+	default:
+		return ""
+	}
+}
+
+// statusWriter wraps an http.ResponseWriter to capture the status code
+// written by the downstream handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.wroteHeader = true
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(http.StatusOK)
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// panicError normalizes a recovered panic value into an error.
+func panicError(rec interface{}) error {
+	if err, ok := rec.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", rec)
+}
+
+// newRequestID generates a random (version 4) UUID for tagging a request.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		rollbarError("failed to generate request id: %s", err.Error())
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
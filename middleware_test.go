@@ -0,0 +1,113 @@
+package rollbar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestNewRequestIDIsUUIDv4(t *testing.T) {
+	id := newRequestID()
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !re.MatchString(id) {
+		t.Fatalf("newRequestID() = %q, want a v4 UUID", id)
+	}
+}
+
+func TestNewRequestIDIsUnique(t *testing.T) {
+	if newRequestID() == newRequestID() {
+		t.Fatal("newRequestID() returned the same id twice in a row")
+	}
+}
+
+func TestMiddlewareLevelForStatus(t *testing.T) {
+	m := &middleware{warningStatus: 400, errorStatus: 500}
+
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{200, ""},
+		{399, ""},
+		{400, "warning"},
+		{404, "warning"},
+		{499, "warning"},
+		{500, "error"},
+		{503, "error"},
+	}
+	for _, tt := range tests {
+		if got := m.levelForStatus(tt.status); got != tt.want {
+			t.Errorf("levelForStatus(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestMiddlewareCustomThresholds(t *testing.T) {
+	m := &middleware{warningStatus: 300, errorStatus: 450}
+	if got := m.levelForStatus(320); got != "warning" {
+		t.Errorf("levelForStatus(320) = %q, want warning", got)
+	}
+	if got := m.levelForStatus(450); got != "error" {
+		t.Errorf("levelForStatus(450) = %q, want error", got)
+	}
+}
+
+func TestMiddlewareReportsServerErrorResponses(t *testing.T) {
+	client := &fakeClient{}
+	handler := MiddlewareFunc(client, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if len(client.calls) != 1 {
+		t.Fatalf("got %d reported calls, want 1", len(client.calls))
+	}
+	if client.calls[0].level != "error" {
+		t.Errorf("level = %q, want error", client.calls[0].level)
+	}
+	if rec.Header().Get(RequestIDHeader) == "" {
+		t.Error("response is missing the request id header")
+	}
+}
+
+func TestMiddlewareHonorsIncomingRequestID(t *testing.T) {
+	client := &fakeClient{}
+	handler := MiddlewareFunc(client, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "given-id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "given-id" {
+		t.Errorf("request id header = %q, want given-id", got)
+	}
+}
+
+func TestMiddlewareRecoversPanicsAndReportsCritical(t *testing.T) {
+	client := &fakeClient{}
+	handler := MiddlewareFunc(client, func(w http.ResponseWriter, r *http.Request) {
+		panic("kaboom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req) // must not panic out of ServeHTTP
+
+	if len(client.calls) != 1 {
+		t.Fatalf("got %d reported calls, want 1", len(client.calls))
+	}
+	if client.calls[0].level != "critical" {
+		t.Errorf("level = %q, want critical", client.calls[0].level)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
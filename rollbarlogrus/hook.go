@@ -0,0 +1,111 @@
+// Package rollbarlogrus adapts a rollbar.Client into a logrus.Hook, so that
+// log entries above a configurable level are also reported to Rollbar.
+package rollbarlogrus
+
+import (
+	"fmt"
+
+	"github.com/rollbar/rollbar-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Hook is a logrus.Hook that reports fired entries to Rollbar.
+type Hook struct {
+	client      rollbar.Client
+	levels      []logrus.Level
+	waitOnFatal bool
+}
+
+// NewHook returns a Hook that fires on the given logrus levels, reporting
+// each entry to client. If no levels are given, it fires on every level in
+// logrus.AllLevels.
+func NewHook(client rollbar.Client, levels ...logrus.Level) *Hook {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return &Hook{client: client, levels: levels}
+}
+
+// WaitOnFatal makes the hook call client.Wait() after reporting a Fatal or
+// Panic entry, before logrus.Logger exits the process or panics, so queued
+// items aren't lost.
+func (h *Hook) WaitOnFatal(wait bool) *Hook {
+	h.waitOnFatal = wait
+	return h
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire implements logrus.Hook.
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	level := rollbarLevel(entry.Level)
+	extras := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		extras[k] = v
+	}
+
+	if err, ok := entry.Data[logrus.ErrorKey].(error); ok {
+		h.client.ErrorWithExtras(level, err, extras)
+	} else {
+		h.client.MessageWithExtras(level, entry.Message, extras)
+	}
+
+	if h.waitOnFatal && (entry.Level == logrus.FatalLevel || entry.Level == logrus.PanicLevel) {
+		h.client.Wait()
+	}
+
+	return nil
+}
+
+// InstallFatalHandler registers a process-wide logrus exit handler (see
+// logrus.RegisterExitHandler) that calls client.Wait() before the process
+// exits due to a logrus.Logger.Fatal call, so items queued by this Hook
+// aren't lost. This is the override logrus itself provides in place of
+// intercepting log.Fatal directly; call it once, e.g. from an init
+// function or early in main.
+func InstallFatalHandler(client rollbar.Client) {
+	logrus.RegisterExitHandler(func() {
+		client.Wait()
+	})
+}
+
+// Recover should be deferred at the top of main (or any goroutine) to
+// catch panics -- including ones raised by logrus.Logger.Panic, which
+// logrus logs but does not itself recover from. It reports the panic to
+// Rollbar at the critical level, blocks until delivery completes, and then
+// re-panics so the process still crashes the way it would have without
+// this package.
+func Recover(client rollbar.Client) {
+	if rec := recover(); rec != nil {
+		client.ErrorWithStackSkip("critical", panicError(rec), 2)
+		client.Wait()
+		panic(rec)
+	}
+}
+
+// panicError normalizes a recovered panic value into an error.
+func panicError(rec interface{}) error {
+	if err, ok := rec.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", rec)
+}
+
+// rollbarLevel maps a logrus level to the severity levels Rollbar expects.
+func rollbarLevel(level logrus.Level) string {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return "critical"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.WarnLevel:
+		return "warning"
+	case logrus.InfoLevel:
+		return "info"
+	default:
+		return "debug"
+	}
+}
@@ -0,0 +1,167 @@
+package rollbarlogrus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rollbar/rollbar-go"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeClient implements rollbar.Client, recording the last Error/Message
+// call instead of making network requests.
+type fakeClient struct {
+	level  string
+	err    error
+	msg    string
+	extras map[string]interface{}
+	waits  int
+}
+
+func (f *fakeClient) SetToken(string)                     {}
+func (f *fakeClient) SetEnvironment(string)                {}
+func (f *fakeClient) SetCodeVersion(string)                {}
+func (f *fakeClient) SetServerHost(string)                 {}
+func (f *fakeClient) SetServerRoot(string)                 {}
+func (f *fakeClient) SetTransport(rollbar.Transport)       {}
+func (f *fakeClient) SetErrorHandler(func(error))          {}
+func (f *fakeClient) SetPerson(id, username, email string) {}
+func (f *fakeClient) SetCustom(map[string]interface{})     {}
+
+func (f *fakeClient) Error(level string, err error) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) ErrorWithExtras(level string, err error, extras map[string]interface{}) {
+	f.level, f.err, f.extras = level, err, extras
+}
+
+func (f *fakeClient) ErrorWithStackSkip(level string, err error, skip int) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) ErrorWithStackSkipWithExtras(level string, err error, skip int, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) RequestError(level string, r *http.Request, err error) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) RequestErrorWithExtras(level string, r *http.Request, err error, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) RequestErrorWithStackSkip(level string, r *http.Request, err error, skip int) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) RequestErrorWithStackSkipWithExtras(level string, r *http.Request, err error, skip int, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) Message(level string, msg string) {
+	f.MessageWithExtras(level, msg, nil)
+}
+
+func (f *fakeClient) MessageWithExtras(level string, msg string, extras map[string]interface{}) {
+	f.level, f.msg, f.extras = level, msg, extras
+}
+
+func (f *fakeClient) SyncError(level string, err error) (string, error) {
+	f.ErrorWithExtras(level, err, nil)
+	return "", nil
+}
+
+func (f *fakeClient) SyncMessage(level string, msg string) (string, error) {
+	f.MessageWithExtras(level, msg, nil)
+	return "", nil
+}
+
+func (f *fakeClient) SyncRequestError(level string, r *http.Request, err error) (string, error) {
+	f.ErrorWithExtras(level, err, nil)
+	return "", nil
+}
+
+func (f *fakeClient) ErrorWithContext(ctx context.Context, level string, err error) (string, error) {
+	return f.SyncError(level, err)
+}
+
+func (f *fakeClient) MessageWithContext(ctx context.Context, level string, msg string) (string, error) {
+	return f.SyncMessage(level, msg)
+}
+
+func (f *fakeClient) RequestErrorWithContext(ctx context.Context, level string, r *http.Request, err error) (string, error) {
+	return f.SyncRequestError(level, r, err)
+}
+
+func (f *fakeClient) Wait() { f.waits++ }
+
+func TestRollbarLevelMapping(t *testing.T) {
+	tests := []struct {
+		level logrus.Level
+		want  string
+	}{
+		{logrus.PanicLevel, "critical"},
+		{logrus.FatalLevel, "critical"},
+		{logrus.ErrorLevel, "error"},
+		{logrus.WarnLevel, "warning"},
+		{logrus.InfoLevel, "info"},
+		{logrus.DebugLevel, "debug"},
+	}
+	for _, tt := range tests {
+		if got := rollbarLevel(tt.level); got != tt.want {
+			t.Errorf("rollbarLevel(%v) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestHookFireReportsErrorKeyAsError(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHook(client)
+
+	entry := &logrus.Entry{
+		Level:   logrus.ErrorLevel,
+		Message: "failed",
+		Data:    logrus.Fields{logrus.ErrorKey: errors.New("boom"), "user_id": 42},
+	}
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() returned an error: %s", err)
+	}
+	if client.level != "error" {
+		t.Errorf("level = %q, want error", client.level)
+	}
+	if client.err == nil || client.err.Error() != "boom" {
+		t.Errorf("err = %v, want boom", client.err)
+	}
+	if client.extras["user_id"] != 42 {
+		t.Errorf("extras[user_id] = %v, want 42", client.extras["user_id"])
+	}
+}
+
+func TestHookFireReportsPlainMessage(t *testing.T) {
+	client := &fakeClient{}
+	hook := NewHook(client)
+
+	entry := &logrus.Entry{Level: logrus.InfoLevel, Message: "hello", Data: logrus.Fields{}}
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire() returned an error: %s", err)
+	}
+	if client.msg != "hello" {
+		t.Errorf("msg = %q, want hello", client.msg)
+	}
+}
+
+func TestPanicErrorWrapsNonErrorValues(t *testing.T) {
+	err := panicError("plain string panic")
+	if err.Error() != "panic: plain string panic" {
+		t.Errorf("panicError() = %q, want a wrapped message", err.Error())
+	}
+
+	original := errors.New("already an error")
+	if panicError(original) != original {
+		t.Error("panicError() should pass through values that are already errors")
+	}
+}
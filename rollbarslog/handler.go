@@ -0,0 +1,126 @@
+// Package rollbarslog adapts a rollbar.Client into an slog.Handler, so that
+// log records are also reported to Rollbar.
+package rollbarslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rollbar/rollbar-go"
+)
+
+// Handler is an slog.Handler that reports records to Rollbar in addition to
+// forwarding them to a wrapped handler (typically one that writes to the
+// process's usual log output).
+type Handler struct {
+	client  rollbar.Client
+	next    slog.Handler
+	attrs   []flatAttr
+	prefix  string
+	onFatal bool
+}
+
+// flatAttr is an attribute whose key already has its enclosing WithGroup
+// path applied, so it can be written directly into the "extras" map
+// without losing slog's grouping.
+type flatAttr struct {
+	key   string
+	value interface{}
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WaitOnFatal makes the handler call client.Wait() after reporting a record
+// at or above slog.LevelError+4 (the level the standard library's log/slog
+// convention reserves for fatal conditions), so queued items aren't lost if
+// the caller exits the process right after logging.
+func WaitOnFatal() Option {
+	return func(h *Handler) {
+		h.onFatal = true
+	}
+}
+
+// NewHandler wraps next, reporting every record next would handle to client
+// as well. Structured attributes are forwarded as extras.
+func NewHandler(client rollbar.Client, next slog.Handler, opts ...Option) *Handler {
+	h := &Handler{client: client, next: next}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	extras := make(map[string]interface{}, record.NumAttrs()+len(h.attrs))
+	for _, a := range h.attrs {
+		extras[a.key] = a.value
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		extras[h.prefix+a.Key] = a.Value.Any()
+		return true
+	})
+
+	level := rollbarLevel(record.Level)
+	if err, ok := extras["error"].(error); ok {
+		h.client.ErrorWithExtras(level, err, extras)
+	} else {
+		h.client.MessageWithExtras(level, record.Message, extras)
+	}
+
+	if h.onFatal && record.Level >= slog.LevelError+4 {
+		h.client.Wait()
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler. Attrs added while a group is open are
+// flattened into extras with that group's path as a dot-joined key prefix,
+// so that attrs sharing a name across different groups don't collide.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flat := make([]flatAttr, len(attrs))
+	for i, a := range attrs {
+		flat[i] = flatAttr{key: h.prefix + a.Key, value: a.Value.Any()}
+	}
+	return &Handler{
+		client:  h.client,
+		next:    h.next.WithAttrs(attrs),
+		attrs:   append(append([]flatAttr{}, h.attrs...), flat...),
+		prefix:  h.prefix,
+		onFatal: h.onFatal,
+	}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{
+		client:  h.client,
+		next:    h.next.WithGroup(name),
+		attrs:   h.attrs,
+		prefix:  h.prefix + name + ".",
+		onFatal: h.onFatal,
+	}
+}
+
+// rollbarLevel maps an slog level to the severity levels Rollbar expects.
+func rollbarLevel(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError+4:
+		return "critical"
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warning"
+	case level >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
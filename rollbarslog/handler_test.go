@@ -0,0 +1,192 @@
+package rollbarslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rollbar/rollbar-go"
+)
+
+// fakeClient implements rollbar.Client, recording the last Error/Message
+// call instead of making network requests.
+type fakeClient struct {
+	level  string
+	err    error
+	msg    string
+	extras map[string]interface{}
+	waits  int
+}
+
+func (f *fakeClient) SetToken(string)                     {}
+func (f *fakeClient) SetEnvironment(string)                {}
+func (f *fakeClient) SetCodeVersion(string)                {}
+func (f *fakeClient) SetServerHost(string)                 {}
+func (f *fakeClient) SetServerRoot(string)                 {}
+func (f *fakeClient) SetTransport(rollbar.Transport)       {}
+func (f *fakeClient) SetErrorHandler(func(error))          {}
+func (f *fakeClient) SetPerson(id, username, email string) {}
+func (f *fakeClient) SetCustom(map[string]interface{})     {}
+
+func (f *fakeClient) Error(level string, err error) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) ErrorWithExtras(level string, err error, extras map[string]interface{}) {
+	f.level, f.err, f.extras = level, err, extras
+}
+
+func (f *fakeClient) ErrorWithStackSkip(level string, err error, skip int) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) ErrorWithStackSkipWithExtras(level string, err error, skip int, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) RequestError(level string, r *http.Request, err error) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) RequestErrorWithExtras(level string, r *http.Request, err error, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) RequestErrorWithStackSkip(level string, r *http.Request, err error, skip int) {
+	f.ErrorWithExtras(level, err, nil)
+}
+
+func (f *fakeClient) RequestErrorWithStackSkipWithExtras(level string, r *http.Request, err error, skip int, extras map[string]interface{}) {
+	f.ErrorWithExtras(level, err, extras)
+}
+
+func (f *fakeClient) Message(level string, msg string) {
+	f.MessageWithExtras(level, msg, nil)
+}
+
+func (f *fakeClient) MessageWithExtras(level string, msg string, extras map[string]interface{}) {
+	f.level, f.msg, f.extras = level, msg, extras
+}
+
+func (f *fakeClient) SyncError(level string, err error) (string, error) {
+	f.ErrorWithExtras(level, err, nil)
+	return "", nil
+}
+
+func (f *fakeClient) SyncMessage(level string, msg string) (string, error) {
+	f.MessageWithExtras(level, msg, nil)
+	return "", nil
+}
+
+func (f *fakeClient) SyncRequestError(level string, r *http.Request, err error) (string, error) {
+	f.ErrorWithExtras(level, err, nil)
+	return "", nil
+}
+
+func (f *fakeClient) ErrorWithContext(ctx context.Context, level string, err error) (string, error) {
+	return f.SyncError(level, err)
+}
+
+func (f *fakeClient) MessageWithContext(ctx context.Context, level string, msg string) (string, error) {
+	return f.SyncMessage(level, msg)
+}
+
+func (f *fakeClient) RequestErrorWithContext(ctx context.Context, level string, r *http.Request, err error) (string, error) {
+	return f.SyncRequestError(level, r, err)
+}
+
+func (f *fakeClient) Wait() { f.waits++ }
+
+func newRecord(level slog.Level, msg string, attrs ...slog.Attr) slog.Record {
+	record := slog.NewRecord(time.Time{}, level, msg, 0)
+	record.AddAttrs(attrs...)
+	return record
+}
+
+func TestRollbarLevelMapping(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  string
+	}{
+		{slog.LevelDebug, "debug"},
+		{slog.LevelInfo, "info"},
+		{slog.LevelWarn, "warning"},
+		{slog.LevelError, "error"},
+		{slog.LevelError + 4, "critical"},
+	}
+	for _, tt := range tests {
+		if got := rollbarLevel(tt.level); got != tt.want {
+			t.Errorf("rollbarLevel(%v) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestHandlePlainRecordReportsMessage(t *testing.T) {
+	client := &fakeClient{}
+	h := NewHandler(client, slog.NewTextHandler(discard{}, nil))
+
+	record := newRecord(slog.LevelInfo, "hello", slog.Int("user_id", 42))
+	if err := h.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle() returned an error: %s", err)
+	}
+	if client.msg != "hello" {
+		t.Errorf("msg = %q, want hello", client.msg)
+	}
+	if client.extras["user_id"] != int64(42) {
+		t.Errorf("extras[user_id] = %v, want 42", client.extras["user_id"])
+	}
+}
+
+func TestHandleAppliesGroupPrefixToAttrs(t *testing.T) {
+	client := &fakeClient{}
+	base := NewHandler(client, slog.NewTextHandler(discard{}, nil))
+
+	grouped := base.WithAttrs([]slog.Attr{slog.String("id", "should-not-collide")}).
+		WithGroup("request").WithAttrs([]slog.Attr{slog.String("id", "abc")})
+	record := newRecord(slog.LevelInfo, "hello")
+
+	if err := grouped.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle() returned an error: %s", err)
+	}
+
+	if client.extras["request.id"] != "abc" {
+		t.Errorf("extras[request.id] = %v, want abc", client.extras["request.id"])
+	}
+	if client.extras["request.id"] == client.extras["id"] {
+		t.Error("grouped attr key collided with the ungrouped record attr of the same name")
+	}
+}
+
+func TestHandleReportsErrorExtraAsError(t *testing.T) {
+	client := &fakeClient{}
+	h := NewHandler(client, slog.NewTextHandler(discard{}, nil))
+
+	boom := errors.New("boom")
+	record := newRecord(slog.LevelError, "failed", slog.Any("error", boom))
+	if err := h.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle() returned an error: %s", err)
+	}
+	if client.err != boom {
+		t.Errorf("err = %v, want boom", client.err)
+	}
+}
+
+func TestHandleWaitsOnFatalWhenConfigured(t *testing.T) {
+	client := &fakeClient{}
+	h := NewHandler(client, slog.NewTextHandler(discard{}, nil), WaitOnFatal())
+
+	record := newRecord(slog.LevelError+4, "dying")
+	if err := h.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle() returned an error: %s", err)
+	}
+	if client.waits != 1 {
+		t.Errorf("waits = %d, want 1", client.waits)
+	}
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
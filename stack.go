@@ -0,0 +1,169 @@
+package rollbar
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+// stackFrame is a single entry in a Rollbar "trace" body, ordered oldest
+// call first.
+type stackFrame struct {
+	Filename string `json:"filename"`
+	Method   string `json:"method"`
+	Lineno   int    `json:"lineno"`
+}
+
+// stackTracer is implemented by errors produced by github.com/pkg/errors
+// (and compatible libraries) that carry their own stack trace, captured at
+// the point the error was created or first wrapped.
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// causer is implemented by github.com/pkg/errors-wrapped errors to expose
+// their underlying cause.
+type causer interface {
+	Cause() error
+}
+
+// errorBody builds the "trace" body for err along with a fingerprint used
+// by Rollbar to group occurrences together. If err, or anything it wraps,
+// implements stackTracer, that captured stack is used instead of a fresh
+// runtime stack taken from the reporting call site -- this keeps the trace
+// pointing at where the error actually originated rather than wherever it
+// happened to be logged. skip is only meaningful for the fallback path.
+func errorBody(err error, skip int) (map[string]interface{}, string) {
+	if tracer, ok := findStackTracer(err); ok {
+		frames := pkgErrorsFrames(tracer)
+		return traceBody(err, frames), fingerprint(errorClass(err), frames)
+	}
+
+	frames := runtimeFrames(skip + 1)
+	return traceBody(err, frames), fingerprint(errorClass(err), frames)
+}
+
+// messageBody builds the body for a plain message (no associated error).
+func messageBody(msg string) map[string]interface{} {
+	return map[string]interface{}{
+		"message": map[string]interface{}{
+			"body": msg,
+		},
+	}
+}
+
+// findStackTracer walks err's entire wrap chain -- via Cause() for
+// github.com/pkg/errors and Unwrap() for the standard library -- and
+// returns the innermost (closest-to-origin) stackTracer found, rather than
+// the first (outermost) one. With more than one wrap layer carrying a
+// stack (e.g. errors.Wrap called twice), the outermost stack only points
+// at the last wrap site; the innermost one points at the actual origin.
+func findStackTracer(err error) (stackTracer, bool) {
+	var deepest stackTracer
+	found := false
+
+	for err != nil {
+		if tracer, ok := err.(stackTracer); ok {
+			deepest = tracer
+			found = true
+		}
+		if c, ok := err.(causer); ok {
+			err = c.Cause()
+			continue
+		}
+		err = errors.Unwrap(err)
+	}
+
+	return deepest, found
+}
+
+// pkgErrorsFrames converts a github.com/pkg/errors stack trace into
+// Rollbar's frame format, oldest call first.
+func pkgErrorsFrames(tracer stackTracer) []stackFrame {
+	pkgFrames := tracer.StackTrace()
+	frames := make([]stackFrame, len(pkgFrames))
+	for i, f := range pkgFrames {
+		lineno, _ := strconv.Atoi(fmt.Sprintf("%d", f))
+		frames[len(pkgFrames)-1-i] = stackFrame{
+			Filename: fmt.Sprintf("%s", f),
+			Method:   fmt.Sprintf("%n", f),
+			Lineno:   lineno,
+		}
+	}
+	return frames
+}
+
+// runtimeFrames captures the current goroutine's stack via runtime.Callers,
+// skipping the given number of frames (in addition to the frames used
+// internally to capture it), oldest call first.
+func runtimeFrames(skip int) []stackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	pcs = pcs[:n]
+
+	callersFrames := runtime.CallersFrames(pcs)
+	var frames []stackFrame
+	for {
+		frame, more := callersFrames.Next()
+		frames = append(frames, stackFrame{
+			Filename: frame.File,
+			Method:   frame.Function,
+			Lineno:   frame.Line,
+		})
+		if !more {
+			break
+		}
+	}
+
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+func traceBody(err error, frames []stackFrame) map[string]interface{} {
+	return map[string]interface{}{
+		"trace": map[string]interface{}{
+			"frames": frames,
+			"exception": map[string]interface{}{
+				"class":   errorClass(err),
+				"message": err.Error(),
+			},
+		},
+	}
+}
+
+// errorClass derives Rollbar's "class" field (used for display and
+// grouping) from err's concrete type.
+func errorClass(err error) string {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" || t.Name() == "" {
+		return t.String()
+	}
+	return fmt.Sprintf("%s.%s", t.PkgPath(), t.Name())
+}
+
+// fingerprint derives a stable grouping key from the exception class and
+// the innermost (closest-to-origin) frames of the trace, rather than from
+// wherever in the tree the error happened to be reported.
+func fingerprint(class string, frames []stackFrame) string {
+	h := sha1.New()
+	fmt.Fprint(h, class)
+
+	innermost := frames
+	if len(innermost) > 3 {
+		innermost = innermost[len(innermost)-3:]
+	}
+	for _, f := range innermost {
+		fmt.Fprintf(h, "%s:%s:%d", f.Filename, f.Method, f.Lineno)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
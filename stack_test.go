@@ -0,0 +1,77 @@
+package rollbar
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestErrorBodyFallsBackToRuntimeStack(t *testing.T) {
+	err := errors.New("plain error")
+	body, fp := errorBody(err, 0)
+
+	trace, ok := body["trace"].(map[string]interface{})
+	if !ok {
+		t.Fatal("body[\"trace\"] is not a map")
+	}
+	frames, ok := trace["frames"].([]stackFrame)
+	if !ok || len(frames) == 0 {
+		t.Fatal("expected at least one runtime-captured frame")
+	}
+	if fp == "" {
+		t.Error("expected a non-empty fingerprint")
+	}
+}
+
+func TestErrorBodyUsesPkgErrorsStack(t *testing.T) {
+	err := pkgerrors.New("wrapped error")
+	body, _ := errorBody(err, 0)
+
+	trace := body["trace"].(map[string]interface{})
+	frames := trace["frames"].([]stackFrame)
+	if len(frames) == 0 {
+		t.Fatal("expected frames from the pkg/errors stack trace")
+	}
+}
+
+func TestFindStackTracerReturnsInnermost(t *testing.T) {
+	origin := pkgerrors.New("origin")
+	wrapped := pkgerrors.Wrap(pkgerrors.Wrap(origin, "middle"), "outer")
+
+	tracer, ok := findStackTracer(wrapped)
+	if !ok {
+		t.Fatal("expected to find a stackTracer")
+	}
+
+	innermost, _ := findStackTracer(origin)
+	if tracer.StackTrace()[0] != innermost.StackTrace()[0] {
+		t.Errorf("got a stack starting at %v, want the origin's stack starting at %v", tracer.StackTrace()[0], innermost.StackTrace()[0])
+	}
+}
+
+func TestFingerprintIsStableAndDiffersByClass(t *testing.T) {
+	frames := []stackFrame{{Filename: "a.go", Method: "f", Lineno: 10}}
+
+	fp1 := fingerprint("errorString", frames)
+	fp2 := fingerprint("errorString", frames)
+	if fp1 != fp2 {
+		t.Error("fingerprint() is not deterministic for identical input")
+	}
+
+	fp3 := fingerprint("otherType", frames)
+	if fp1 == fp3 {
+		t.Error("fingerprint() did not vary with a different error class")
+	}
+}
+
+func TestMessageBody(t *testing.T) {
+	body := messageBody("hello")
+	msg, ok := body["message"].(map[string]interface{})
+	if !ok {
+		t.Fatal("body[\"message\"] is not a map")
+	}
+	if msg["body"] != "hello" {
+		t.Errorf("message body = %v, want hello", msg["body"])
+	}
+}
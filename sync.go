@@ -0,0 +1,77 @@
+package rollbar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SyncError sends an error to Rollbar synchronously, bypassing bodyChannel,
+// and returns the item UUID assigned by Rollbar. It is equivalent to
+// ErrorWithContext with a background context.
+func (c *Rollbar) SyncError(level string, err error) (string, error) {
+	return c.ErrorWithContext(context.Background(), level, err)
+}
+
+// ErrorWithContext is the context-aware sibling of SyncError. The context
+// bounds the entire send, including retries, so callers can set a deadline
+// for how long they're willing to wait on a slow or unreachable Rollbar.
+func (c *Rollbar) ErrorWithContext(ctx context.Context, level string, err error) (string, error) {
+	body := c.buildBody(level, err.Error(), noExtras)
+	data := body["data"].(map[string]interface{})
+	errBody, fingerprint := errorBody(err, 1)
+	data["body"] = errBody
+	data["fingerprint"] = fingerprint
+
+	return c.postSync(ctx, body)
+}
+
+// SyncMessage sends a message to Rollbar synchronously, bypassing
+// bodyChannel, and returns the item UUID assigned by Rollbar.
+func (c *Rollbar) SyncMessage(level string, msg string) (string, error) {
+	return c.MessageWithContext(context.Background(), level, msg)
+}
+
+// MessageWithContext is the context-aware sibling of SyncMessage.
+func (c *Rollbar) MessageWithContext(ctx context.Context, level string, msg string) (string, error) {
+	body := c.buildBody(level, msg, noExtras)
+	data := body["data"].(map[string]interface{})
+	data["body"] = messageBody(msg)
+
+	return c.postSync(ctx, body)
+}
+
+// SyncRequestError sends an error with request-specific information to
+// Rollbar synchronously, bypassing bodyChannel, and returns the item UUID
+// assigned by Rollbar.
+func (c *Rollbar) SyncRequestError(level string, r *http.Request, err error) (string, error) {
+	return c.RequestErrorWithContext(context.Background(), level, r, err)
+}
+
+// RequestErrorWithContext is the context-aware sibling of SyncRequestError.
+func (c *Rollbar) RequestErrorWithContext(ctx context.Context, level string, r *http.Request, err error) (string, error) {
+	body := c.buildBody(level, err.Error(), noExtras)
+	data := body["data"].(map[string]interface{})
+	errBody, fingerprint := errorBody(err, 1)
+	data["body"] = errBody
+	data["fingerprint"] = fingerprint
+	data["request"] = c.errorRequest(r)
+
+	return c.postSync(ctx, body)
+}
+
+// postSync delivers body immediately rather than queueing it on
+// bodyChannel, using ctx to bound the send if the configured Transport
+// supports per-request deadlines.
+func (c *Rollbar) postSync(ctx context.Context, body map[string]interface{}) (string, error) {
+	if len(c.Token) == 0 {
+		err := fmt.Errorf("empty token")
+		rollbarError(err.Error())
+		return "", err
+	}
+
+	if ct, ok := c.Transport.(ContextTransport); ok {
+		return ct.PostWithContext(ctx, c.Endpoint, body)
+	}
+	return c.Transport.Post(c.Endpoint, body)
+}
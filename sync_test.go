@@ -0,0 +1,132 @@
+package rollbar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeTransport is a Transport (and ContextTransport) test double that
+// records whether it was invoked via the context-aware path.
+type fakeTransport struct {
+	uuid       string
+	err        error
+	viaContext bool
+	gotCtx     context.Context
+}
+
+func (f *fakeTransport) Post(endpoint string, body map[string]interface{}) (string, error) {
+	return f.uuid, f.err
+}
+
+func (f *fakeTransport) PostWithContext(ctx context.Context, endpoint string, body map[string]interface{}) (string, error) {
+	f.viaContext = true
+	f.gotCtx = ctx
+	return f.uuid, f.err
+}
+
+func newTestRollbar(transport Transport) *Rollbar {
+	return &Rollbar{
+		Token:     "test-token",
+		Endpoint:  "https://example.invalid/item/",
+		Transport: transport,
+	}
+}
+
+func TestSyncErrorUsesContextTransport(t *testing.T) {
+	ft := &fakeTransport{uuid: "item-uuid"}
+	c := newTestRollbar(ft)
+
+	uuid, err := c.SyncError("error", errors.New("boom"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "item-uuid" {
+		t.Errorf("uuid = %q, want item-uuid", uuid)
+	}
+	if !ft.viaContext {
+		t.Error("SyncError did not use the ContextTransport path")
+	}
+}
+
+func TestSyncMessageReturnsUUID(t *testing.T) {
+	ft := &fakeTransport{uuid: "msg-uuid"}
+	c := newTestRollbar(ft)
+
+	uuid, err := c.SyncMessage("info", "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "msg-uuid" {
+		t.Errorf("uuid = %q, want msg-uuid", uuid)
+	}
+}
+
+func TestSyncRequestErrorAttachesRequest(t *testing.T) {
+	ft := &fakeTransport{uuid: "req-uuid"}
+	c := newTestRollbar(ft)
+
+	req := httptest.NewRequest(http.MethodGet, "/path?token=secret", nil)
+	uuid, err := c.SyncRequestError("error", req, errors.New("boom"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "req-uuid" {
+		t.Errorf("uuid = %q, want req-uuid", uuid)
+	}
+}
+
+func TestErrorWithContextPassesDeadlineThrough(t *testing.T) {
+	ft := &fakeTransport{uuid: "ctx-uuid"}
+	c := newTestRollbar(ft)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := c.ErrorWithContext(ctx, "error", errors.New("boom")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ft.gotCtx != ctx {
+		t.Error("ErrorWithContext did not forward the caller's context")
+	}
+}
+
+func TestSyncSendWithEmptyTokenErrors(t *testing.T) {
+	ft := &fakeTransport{uuid: "should-not-be-used"}
+	c := newTestRollbar(ft)
+	c.Token = ""
+
+	if _, err := c.SyncError("error", errors.New("boom")); err == nil {
+		t.Fatal("expected an error for an empty token")
+	}
+	if ft.viaContext {
+		t.Error("transport should not have been invoked with an empty token")
+	}
+}
+
+func TestPostSyncFallsBackWithoutContextTransport(t *testing.T) {
+	// Transport implements only the plain Transport interface.
+	basic := &fakeTransport{uuid: "basic-uuid"}
+	var t_ Transport = &basicTransport{inner: basic}
+	c := newTestRollbar(t_)
+
+	uuid, err := c.SyncMessage("info", "hi")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "basic-uuid" {
+		t.Errorf("uuid = %q, want basic-uuid", uuid)
+	}
+}
+
+// basicTransport wraps a fakeTransport but only exposes the plain Post
+// method, so it does not satisfy ContextTransport.
+type basicTransport struct {
+	inner *fakeTransport
+}
+
+func (b *basicTransport) Post(endpoint string, body map[string]interface{}) (string, error) {
+	return b.inner.Post(endpoint, body)
+}
@@ -0,0 +1,249 @@
+package rollbar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Transport is the interface responsible for delivering a built payload to
+// the Rollbar API. It is settable via Rollbar.SetTransport so that callers
+// can swap in their own delivery strategy (a different retry policy, a
+// queueing system, a test double, etc). Post returns the item UUID assigned
+// by the Rollbar API, if any, and an error if the item could not be
+// delivered.
+type Transport interface {
+	Post(endpoint string, body map[string]interface{}) (uuid string, err error)
+}
+
+// ContextTransport is an optional extension of Transport for implementations
+// that can honor a per-request deadline, used by Client's *WithContext
+// methods. Transports that don't implement it simply ignore the context.
+type ContextTransport interface {
+	PostWithContext(ctx context.Context, endpoint string, body map[string]interface{}) (uuid string, err error)
+}
+
+// HTTPTransport is the default Transport. It retries network errors and 5xx
+// responses with exponential backoff and jitter, honors Retry-After on 429
+// responses, and gives up after MaxRetries attempts or MaxElapsedTime,
+// whichever comes first.
+type HTTPTransport struct {
+	// Client is the underlying HTTP client used to make requests.
+	Client *http.Client
+	// MaxRetries is the maximum number of attempts made for a single item,
+	// including the first one. The zero value uses DefaultMaxRetries.
+	MaxRetries int
+	// MaxElapsedTime bounds the total time spent retrying a single item.
+	// The zero value uses DefaultMaxElapsedTime.
+	MaxElapsedTime time.Duration
+	// InitialInterval is the base delay before the first retry. Subsequent
+	// retries back off exponentially from this value. The zero value uses
+	// DefaultInitialInterval.
+	InitialInterval time.Duration
+
+	// errorHandler, if set via Rollbar.SetErrorHandler, is called with any
+	// error that results in an item being dropped.
+	errorHandler func(error)
+
+	dropped uint64
+	retries uint64
+}
+
+// DefaultMaxRetries, DefaultMaxElapsedTime and DefaultInitialInterval are the
+// retry parameters used by a zero-value HTTPTransport.
+const (
+	DefaultMaxRetries      = 5
+	DefaultMaxElapsedTime  = 30 * time.Second
+	DefaultInitialInterval = 250 * time.Millisecond
+)
+
+// rollbarResponse mirrors the JSON body Rollbar's item endpoint responds
+// with.
+type rollbarResponse struct {
+	Err     int    `json:"err"`
+	Message string `json:"message"`
+	Result  struct {
+		UUID string `json:"uuid"`
+	} `json:"result"`
+}
+
+// NewHTTPTransport returns an HTTPTransport configured with the package
+// defaults.
+func NewHTTPTransport() *HTTPTransport {
+	return &HTTPTransport{
+		Client:          &http.Client{},
+		MaxRetries:      DefaultMaxRetries,
+		MaxElapsedTime:  DefaultMaxElapsedTime,
+		InitialInterval: DefaultInitialInterval,
+	}
+}
+
+// DroppedCount returns the number of items this transport has permanently
+// failed to deliver.
+func (t *HTTPTransport) DroppedCount() uint64 {
+	return atomic.LoadUint64(&t.dropped)
+}
+
+// RetryCount returns the number of retry attempts this transport has made
+// across all items.
+func (t *HTTPTransport) RetryCount() uint64 {
+	return atomic.LoadUint64(&t.retries)
+}
+
+// Post implements Transport. It is equivalent to PostWithContext with a
+// background context.
+func (t *HTTPTransport) Post(endpoint string, body map[string]interface{}) (string, error) {
+	return t.PostWithContext(context.Background(), endpoint, body)
+}
+
+// PostWithContext implements ContextTransport. The context bounds the
+// entire retry loop: once it is done, no further attempts are made and the
+// item is dropped.
+func (t *HTTPTransport) PostWithContext(ctx context.Context, endpoint string, body map[string]interface{}) (string, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return "", t.giveUp(fmt.Errorf("failed to encode payload: %s", err.Error()))
+	}
+
+	maxRetries := t.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	maxElapsedTime := t.MaxElapsedTime
+	if maxElapsedTime == 0 {
+		maxElapsedTime = DefaultMaxElapsedTime
+	}
+	initialInterval := t.InitialInterval
+	if initialInterval == 0 {
+		initialInterval = DefaultInitialInterval
+	}
+	client := t.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	start := time.Now()
+	var lastErr error
+retryLoop:
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if ctx.Err() != nil {
+			lastErr = ctx.Err()
+			break retryLoop
+		}
+		if attempt > 0 {
+			atomic.AddUint64(&t.retries, 1)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(jsonBody))
+		if err != nil {
+			return "", t.giveUp(fmt.Errorf("failed to build request: %s", err.Error()))
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("POST failed: %s", err.Error())
+			if time.Since(start) > maxElapsedTime {
+				break retryLoop
+			}
+			sleep(ctx, backoff(attempt, initialInterval))
+			continue
+		}
+
+		uuid, rerr := decodeResponse(resp)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			lastErr = fmt.Errorf("rate limited: %s", resp.Status)
+			if time.Since(start) > maxElapsedTime {
+				break retryLoop
+			}
+			sleep(ctx, retryAfter(resp, attempt, initialInterval))
+			continue
+		case resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("received response: %s", resp.Status)
+			if time.Since(start) > maxElapsedTime {
+				break retryLoop
+			}
+			sleep(ctx, backoff(attempt, initialInterval))
+			continue
+		case resp.StatusCode != 200:
+			return "", t.giveUp(fmt.Errorf("received response: %s", resp.Status))
+		case rerr != nil:
+			return "", t.giveUp(rerr)
+		default:
+			return uuid, nil
+		}
+	}
+
+	return "", t.giveUp(lastErr)
+}
+
+func (t *HTTPTransport) giveUp(err error) error {
+	if err == nil {
+		return nil
+	}
+	atomic.AddUint64(&t.dropped, 1)
+	if t.errorHandler != nil {
+		t.errorHandler(err)
+	} else {
+		rollbarError(err.Error())
+	}
+	return err
+}
+
+// decodeResponse reads Rollbar's {"err": N, "message": "..."} response body
+// and returns the assigned item UUID, or an error describing a non-zero
+// "err" field.
+func decodeResponse(resp *http.Response) (string, error) {
+	var parsed rollbarResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", nil // best-effort: a malformed body isn't fatal on an otherwise-200 response
+	}
+	if parsed.Err != 0 {
+		return "", fmt.Errorf("rollbar: %s", parsed.Message)
+	}
+	return parsed.Result.UUID, nil
+}
+
+// retryAfter honors a 429 response's Retry-After header when present, in
+// either of the two forms RFC 7231 allows -- a number of seconds, or an
+// HTTP-date -- falling back to the normal exponential backoff otherwise.
+func retryAfter(resp *http.Response, attempt int, initialInterval time.Duration) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			if d := time.Until(at); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+	return backoff(attempt, initialInterval)
+}
+
+// sleep waits for d, returning early if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// backoff returns an exponentially increasing delay with jitter.
+func backoff(attempt int, initialInterval time.Duration) time.Duration {
+	d := initialInterval << uint(attempt)
+	jitter := time.Duration(rand.Int63n(int64(d) + 1))
+	return d/2 + jitter/2
+}
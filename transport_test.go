@@ -0,0 +1,157 @@
+package rollbar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffIsBoundedAndGrows(t *testing.T) {
+	initial := 10 * time.Millisecond
+	for attempt := 0; attempt < 5; attempt++ {
+		d := backoff(attempt, initial)
+		max := initial << uint(attempt)
+		if d < max/2 || d > max {
+			t.Errorf("backoff(%d) = %s, want in [%s, %s]", attempt, d, max/2, max)
+		}
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"5"}}}
+	got := retryAfter(resp, 0, 10*time.Millisecond)
+	if got != 5*time.Second {
+		t.Errorf("retryAfter() = %s, want 5s", got)
+	}
+}
+
+func TestRetryAfterHTTPDate(t *testing.T) {
+	future := time.Now().Add(30 * time.Second)
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{future.UTC().Format(http.TimeFormat)}}}
+	got := retryAfter(resp, 0, 10*time.Millisecond)
+	if got <= 0 || got > 30*time.Second {
+		t.Errorf("retryAfter() = %s, want roughly 30s", got)
+	}
+}
+
+func TestRetryAfterPastHTTPDateIsZero(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{past.UTC().Format(http.TimeFormat)}}}
+	if got := retryAfter(resp, 0, 10*time.Millisecond); got != 0 {
+		t.Errorf("retryAfter() = %s, want 0", got)
+	}
+}
+
+func TestRetryAfterFallsBackToBackoff(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	got := retryAfter(resp, 0, 10*time.Millisecond)
+	if got > 10*time.Millisecond {
+		t.Errorf("retryAfter() = %s, want fallback backoff bound", got)
+	}
+}
+
+func TestDecodeResponseSurfacesAPIError(t *testing.T) {
+	resp := httptest.NewRecorder()
+	resp.Body.WriteString(`{"err":1,"message":"invalid access token"}`)
+	_, err := decodeResponse(resp.Result())
+	if err == nil {
+		t.Fatal("expected an error for a non-zero \"err\" field")
+	}
+}
+
+func TestDecodeResponseReturnsUUID(t *testing.T) {
+	resp := httptest.NewRecorder()
+	resp.Body.WriteString(`{"err":0,"result":{"uuid":"abc-123"}}`)
+	uuid, err := decodeResponse(resp.Result())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "abc-123" {
+		t.Errorf("uuid = %q, want abc-123", uuid)
+	}
+}
+
+func TestHTTPTransportRetriesThenSucceeds(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"err":0,"result":{"uuid":"done"}}`))
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{MaxRetries: 5, InitialInterval: time.Millisecond, MaxElapsedTime: time.Second}
+	uuid, err := transport.Post(server.URL, map[string]interface{}{"x": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if uuid != "done" {
+		t.Errorf("uuid = %q, want done", uuid)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestHTTPTransportGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{MaxRetries: 3, InitialInterval: time.Millisecond, MaxElapsedTime: time.Second}
+	_, err := transport.Post(server.URL, map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+	if got := transport.DroppedCount(); got != 1 {
+		t.Errorf("DroppedCount() = %d, want 1", got)
+	}
+}
+
+func TestHTTPTransportHonorsRetryAfterOn429(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"err":0}`))
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{MaxRetries: 3, InitialInterval: time.Millisecond, MaxElapsedTime: time.Second}
+	_, err := transport.Post(server.URL, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestHTTPTransportInvokesErrorHandlerOnDrop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	var handled error
+	transport := &HTTPTransport{MaxRetries: 1, InitialInterval: time.Millisecond, errorHandler: func(err error) { handled = err }}
+	_, _ = transport.Post(server.URL, map[string]interface{}{})
+	if handled == nil {
+		t.Fatal("expected the error handler to be invoked")
+	}
+}